@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config mirrors the subset of CLI flags that operators also want to set
+// from a file: output shaping, SID allow/deny filtering, where output goes,
+// and a severity remapping table applied on top of classtype.
+type config struct {
+	Pretty     *bool             `yaml:"pretty"`
+	IncludeRaw *bool             `yaml:"include_raw"`
+	OutDir     *string           `yaml:"out_dir"`
+	SIDAllow   []int             `yaml:"sid_allow"`
+	SIDDeny    []int             `yaml:"sid_deny"`
+	Severity   map[string]string `yaml:"severity_remap"`
+}
+
+// loadConfig reads a YAML config file as described in --config's help text.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// applyConfig layers cfg over the CLI flags, letting an explicitly passed
+// flag win over the same setting in the config file.
+func applyConfig(cfg *config) {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if cfg.Pretty != nil && !explicit["pretty"] {
+		*prettyPrint = *cfg.Pretty
+	}
+	if cfg.IncludeRaw != nil && !explicit["raw"] {
+		*includeRaw = *cfg.IncludeRaw
+	}
+	if cfg.OutDir != nil && !explicit["out-dir"] {
+		*outDir = *cfg.OutDir
+	}
+
+	sidAllow = toSIDSet(cfg.SIDAllow)
+	sidDeny = toSIDSet(cfg.SIDDeny)
+	severityRemap = cfg.Severity
+}
+
+func toSIDSet(sids []int) map[int]bool {
+	if len(sids) == 0 {
+		return nil
+	}
+	set := make(map[int]bool, len(sids))
+	for _, sid := range sids {
+		set[sid] = true
+	}
+	return set
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// lineRecord is a single candidate rule line read from the input, tagged
+// with its sequence number (so the writer can restore input order) and the
+// physical source line it started on (so parse errors can point the user at
+// the right place in the file even once comments, blank lines, and
+// backslash continuations have been filtered/joined out of the stream).
+type lineRecord struct {
+	seq  int
+	line int
+	text string
+}
+
+// lineResult is what a worker produces for a lineRecord: either marshaled
+// NDJSON data, nothing (the rule was filtered out by --sid), or a parse
+// error. line carries the source lineRecord's physical line number through
+// to the writer for logging.
+type lineResult struct {
+	seq      int
+	line     int
+	data     []byte
+	parseErr error
+}
+
+// scanJoinedLines scans r line by line, joining backslash-continued
+// physical lines into a single logical line, skipping blank lines and "#"
+// comments, and calling emit with the logical line's text and the physical
+// source line it started on. It's factored out of convertFile's scanning
+// goroutine so the continuation-joining behavior can be unit tested without
+// a real file.
+func scanJoinedLines(r io.Reader, emit func(text string, line int)) error {
+	scanner := bufio.NewScanner(r)
+	physicalLine := 0
+	recordStart := 0
+
+	flush := func(text string) {
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			return
+		}
+		emit(text, recordStart)
+	}
+
+	var pending strings.Builder
+	continuing := false
+	for scanner.Scan() {
+		physicalLine++
+		if !continuing {
+			recordStart = physicalLine
+		}
+
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
+		if continuing {
+			pending.WriteByte(' ')
+			raw = strings.TrimLeft(raw, " \t")
+		}
+
+		if strings.HasSuffix(raw, "\\") {
+			pending.WriteString(strings.TrimRight(strings.TrimSuffix(raw, "\\"), " \t"))
+			continuing = true
+			continue
+		}
+
+		pending.WriteString(raw)
+		continuing = false
+		joined := pending.String()
+		pending.Reset()
+		flush(joined)
+	}
+	if continuing && pending.Len() > 0 {
+		flush(pending.String())
+	}
+
+	return scanner.Err()
+}
+
+// convertFile parses every rule in inputPath and writes the resulting NDJSON
+// to outputPath, creating any missing parent directories. It returns the
+// number of rules successfully converted and the number that failed to
+// parse or marshal.
+//
+// Internally it runs a producer/worker/writer pipeline: one goroutine scans
+// lines (joining backslash-continued physical lines into a single logical
+// rule first), *workers goroutines parse and marshal them concurrently, and
+// the calling goroutine writes NDJSON out in input order (or arrival order
+// with --unordered) so large rule sets aren't CPU-bound on a single core.
+func convertFile(inputPath, outputPath string) (ruleCount, errorCount int, err error) {
+	var file *os.File
+	if inputPath == "-" {
+		file = os.Stdin
+	} else {
+		file, err = os.Open(inputPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("opening %s: %w", inputPath, err)
+		}
+		defer file.Close()
+	}
+
+	var outputWriter *os.File
+	if outputPath == "-" {
+		outputWriter = os.Stdout
+	} else {
+		if dir := filepath.Dir(outputPath); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return 0, 0, fmt.Errorf("creating output dir for %s: %w", outputPath, err)
+			}
+		}
+
+		outputWriter, err = os.Create(outputPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("creating %s: %w", outputPath, err)
+		}
+		defer outputWriter.Close()
+	}
+
+	log.Printf("Converting %s -> %s", inputPath, outputPath)
+
+	lines := make(chan lineRecord, 256)
+	results := make(chan lineResult, 256)
+
+	workerCount := *workers
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	var workerWG sync.WaitGroup
+	workerWG.Add(workerCount)
+	for i := 0; i < workerCount; i++ {
+		go func() {
+			defer workerWG.Done()
+			for rec := range lines {
+				results <- parseLine(rec)
+			}
+		}()
+	}
+	go func() {
+		workerWG.Wait()
+		close(results)
+	}()
+
+	var scanErr error
+	go func() {
+		defer close(lines)
+		seq := 0
+		scanErr = scanJoinedLines(file, func(text string, line int) {
+			lines <- lineRecord{seq: seq, line: line, text: text}
+			seq++
+		})
+	}()
+
+	if *unordered {
+		ruleCount, errorCount = writeUnordered(outputWriter, results, inputPath)
+	} else {
+		ruleCount, errorCount = writeOrdered(outputWriter, results, inputPath)
+	}
+
+	if scanErr != nil {
+		return ruleCount, errorCount, fmt.Errorf("reading %s: %w", inputPath, scanErr)
+	}
+
+	log.Printf("Successfully processed %d rules to %s", ruleCount, outputPath)
+	if errorCount > 0 {
+		log.Printf("%s: %d rules succeeded, %d errors", inputPath, ruleCount, errorCount)
+	}
+
+	return ruleCount, errorCount, nil
+}
+
+// parseLine parses and marshals a single rule line, applying --sid
+// filtering. A nil data with a nil parseErr means the rule was filtered out.
+func parseLine(rec lineRecord) lineResult {
+	rule, parseErr := parseEnhancedRule(rec.text)
+	if parseErr != nil {
+		if pe, ok := parseErr.(*parseError); ok {
+			pe.Line = rec.line
+		}
+		return lineResult{seq: rec.seq, line: rec.line, parseErr: parseErr}
+	}
+
+	if *filterSID != "" && rule.Metadata != nil {
+		if strconv.Itoa(rule.Metadata.SID) != *filterSID {
+			return lineResult{seq: rec.seq, line: rec.line}
+		}
+	}
+
+	if rule.Metadata != nil {
+		if sidDeny[rule.Metadata.SID] {
+			return lineResult{seq: rec.seq, line: rec.line}
+		}
+		if sidAllow != nil && !sidAllow[rule.Metadata.SID] {
+			return lineResult{seq: rec.seq, line: rec.line}
+		}
+	}
+
+	var jsonData []byte
+	var jsonErr error
+	if *prettyPrint {
+		jsonData, jsonErr = json.MarshalIndent(rule, "", "  ")
+	} else {
+		jsonData, jsonErr = json.Marshal(rule)
+	}
+	if jsonErr != nil {
+		return lineResult{seq: rec.seq, line: rec.line, parseErr: jsonErr}
+	}
+
+	return lineResult{seq: rec.seq, line: rec.line, data: jsonData}
+}
+
+// writeUnordered emits each result as soon as it arrives, in whatever order
+// the workers finish.
+func writeUnordered(w *os.File, results <-chan lineResult, inputPath string) (ruleCount, errorCount int) {
+	for res := range results {
+		if res.parseErr != nil {
+			log.Printf("Error parsing rule (line %d) in %s: %v", res.line, inputPath, res.parseErr)
+			errorCount++
+			continue
+		}
+		if res.data == nil {
+			continue
+		}
+		fmt.Fprintln(w, string(res.data))
+		ruleCount++
+	}
+	return ruleCount, errorCount
+}
+
+// writeOrdered buffers out-of-order results so they're written in the same
+// sequence the lines appeared in the input file.
+func writeOrdered(w *os.File, results <-chan lineResult, inputPath string) (ruleCount, errorCount int) {
+	pending := make(map[int]lineResult)
+	next := 0
+
+	emit := func(res lineResult) {
+		if res.parseErr != nil {
+			log.Printf("Error parsing rule (line %d) in %s: %v", res.line, inputPath, res.parseErr)
+			errorCount++
+			return
+		}
+		if res.data == nil {
+			return
+		}
+		fmt.Fprintln(w, string(res.data))
+		ruleCount++
+	}
+
+	for res := range results {
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			emit(res)
+		}
+	}
+
+	return ruleCount, errorCount
+}
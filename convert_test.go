@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestScanJoinedLines covers the cases the chunk0-5 tokenizer rewrite was
+// meant to stop dropping silently: backslash-continued multi-line rules,
+// comments and blank lines interleaved with rules, and physical source line
+// numbers that survive all of the above.
+func TestScanJoinedLines(t *testing.T) {
+	type want struct {
+		text string
+		line int
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []want
+	}{
+		{
+			name:  "single line rule",
+			input: `alert tcp any any -> any any (sid:1; rev:1;)` + "\n",
+			want: []want{
+				{text: `alert tcp any any -> any any (sid:1; rev:1;)`, line: 1},
+			},
+		},
+		{
+			name: "comments and blank lines don't shift later line numbers",
+			input: "# header comment\n" +
+				"\n" +
+				`alert tcp any any -> any any (msg:"ok"; sid:1; rev:1;)` + "\n" +
+				`alert tcp any any => any any (msg:"bad"; sid:2; rev:1;)` + "\n",
+			want: []want{
+				{text: `alert tcp any any -> any any (msg:"ok"; sid:1; rev:1;)`, line: 3},
+				{text: `alert tcp any any => any any (msg:"bad"; sid:2; rev:1;)`, line: 4},
+			},
+		},
+		{
+			name: "multi-line rule joined on backslash continuation",
+			input: `alert tcp any any -> any any (msg:"multiline test"; \` + "\n" +
+				`content:"foo"; sid:100; rev:1;)` + "\n",
+			want: []want{
+				{text: `alert tcp any any -> any any (msg:"multiline test"; content:"foo"; sid:100; rev:1;)`, line: 1},
+			},
+		},
+		{
+			name: "rule following a multi-line rule gets the right line number",
+			input: `alert tcp any any -> any any (msg:"multiline"; \` + "\n" +
+				`sid:1; rev:1;)` + "\n" +
+				`alert tcp any any -> any any (msg:"next"; sid:2; rev:1;)` + "\n",
+			want: []want{
+				{text: `alert tcp any any -> any any (msg:"multiline"; sid:1; rev:1;)`, line: 1},
+				{text: `alert tcp any any -> any any (msg:"next"; sid:2; rev:1;)`, line: 3},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []want
+			err := scanJoinedLines(strings.NewReader(tc.input), func(text string, line int) {
+				got = append(got, want{text: text, line: line})
+			})
+			if err != nil {
+				t.Fatalf("scanJoinedLines: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// discoverRuleFiles resolves root into the .rules files it describes. A
+// plain directory is recursively walked for files ending in ".rules"
+// (sorted by filepath.WalkDir's natural directory order). A root containing
+// glob metacharacters ('*', '?', '[') is instead treated as a glob pattern,
+// e.g. "/rules/*.rules" or, with "**" matching any number of directories,
+// "/rules/**/*.rules" for a recursive match.
+func discoverRuleFiles(root string) ([]string, error) {
+	if strings.ContainsAny(root, "*?[") {
+		return globRuleFiles(root)
+	}
+
+	var files []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".rules") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", root, err)
+	}
+
+	return files, nil
+}
+
+// globRuleFiles matches pattern against the filesystem. Without "**" it's a
+// thin wrapper around filepath.Glob; with "**" it walks the non-wildcard
+// directory prefix before "**" and matches the remainder of the pattern
+// (itself still a filepath.Match pattern) against each file found at any
+// depth under that prefix, either by its path relative to the prefix or by
+// its base name alone (so "**/*.rules" matches files in the prefix
+// directory itself, not only its subdirectories).
+func globRuleFiles(pattern string) ([]string, error) {
+	idx := strings.Index(pattern, "**")
+	if idx == -1 {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("matching glob %s: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	base := filepath.Clean(pattern[:idx])
+	suffix := strings.TrimPrefix(pattern[idx+2:], string(filepath.Separator))
+
+	var files []string
+	err := filepath.WalkDir(base, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if rel, err := filepath.Rel(base, path); err == nil {
+			if ok, _ := filepath.Match(suffix, rel); ok {
+				files = append(files, path)
+				return nil
+			}
+		}
+		if ok, _ := filepath.Match(suffix, d.Name()); ok {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("matching glob %s: %w", pattern, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// globBaseDir returns the longest directory prefix of a glob pattern that
+// contains no glob metacharacters, so callers mirroring --out-dir trees
+// have something directory-shaped to compute paths relative to when
+// --rules-path is a glob pattern rather than a plain directory.
+func globBaseDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// discoverRuleDirs returns root and every subdirectory beneath it. fsnotify
+// only watches a single directory (no recursive option), so --watch needs
+// the full directory list to mirror discoverRuleFiles' recursive scan of
+// --rules-path instead of missing changes in nested directories.
+func discoverRuleDirs(root string) ([]string, error) {
+	var dirs []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", root, err)
+	}
+
+	return dirs, nil
+}
+
+// readRulesList reads a list file containing one rules-file path per line,
+// ignoring blank lines and "#" comments.
+func readRulesList(listPath string) ([]string, error) {
+	file, err := os.Open(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening rules list: %w", err)
+	}
+	defer file.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		files = append(files, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading rules list: %w", err)
+	}
+
+	return files, nil
+}
+
+// outputPathFor computes the output NDJSON path for inputPath. When outDir is
+// empty the file is written alongside the input (generateOutputFilename).
+// Otherwise the result mirrors inputPath's location relative to baseDir
+// underneath outDir; if inputPath isn't under baseDir (or baseDir is empty)
+// the file is placed directly in outDir.
+func outputPathFor(inputPath, baseDir, outDir string) string {
+	if outDir == "" {
+		return generateOutputFilename(inputPath)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)) + ".ndjson"
+
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, inputPath); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(outDir, filepath.Dir(rel), name)
+		}
+	}
+
+	return filepath.Join(outDir, name)
+}
@@ -1,15 +1,15 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type EnhancedRule struct {
@@ -21,8 +21,13 @@ type EnhancedRule struct {
 	DestIP      string                 `json:"dest_ip"`
 	DestPort    string                 `json:"dest_port"`
 	Options     map[string]interface{} `json:"options"`
-	ParsedPorts *PortInfo             `json:"parsed_ports,omitempty"`
-	Metadata    *RuleMetadata         `json:"metadata,omitempty"`
+	OptionOrder []string               `json:"option_order,omitempty"`
+	Contents    []ContentMatch         `json:"contents,omitempty"`
+	Pcres       []PcreMatch            `json:"pcres,omitempty"`
+	Flow        *FlowInfo              `json:"flow,omitempty"`
+	Threshold   *ThresholdInfo         `json:"threshold,omitempty"`
+	ParsedPorts *PortInfo              `json:"parsed_ports,omitempty"`
+	Metadata    *RuleMetadata          `json:"metadata,omitempty"`
 	RawRule     string                 `json:"raw_rule"`
 }
 
@@ -32,11 +37,12 @@ type PortInfo struct {
 }
 
 type RuleMetadata struct {
-	CVEs       []string `json:"cves,omitempty"`
-	References []string `json:"references,omitempty"`
-	SID        int      `json:"sid,omitempty"`
-	Revision   int      `json:"revision,omitempty"`
-	Severity   string   `json:"severity,omitempty"`
+	CVEs       []string          `json:"cves,omitempty"`
+	References []Reference       `json:"references,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	SID        int               `json:"sid,omitempty"`
+	Revision   int               `json:"revision,omitempty"`
+	Severity   string            `json:"severity,omitempty"`
 }
 
 var (
@@ -44,91 +50,164 @@ var (
 	includeRaw  = flag.Bool("raw", true, "Include raw rule in output")
 	filterSID   = flag.String("sid", "", "Filter by specific SID")
 	helpFlag    = flag.Bool("help", false, "Show help")
+
+	rulesPath = flag.String("rules-path", "", "Directory to recursively scan for .rules files, or a glob pattern (supports ** for recursive matching)")
+	rulesList = flag.String("rules-list", "", "File listing rules files to process, one per line")
+	outDir    = flag.String("out-dir", "", "Output directory; mirrors the input tree when used with --rules-path or --rules-list")
+	jobs      = flag.Int("jobs", 1, "Number of files to convert concurrently")
+
+	workers   = flag.Int("workers", runtime.NumCPU(), "Number of goroutines parsing rules within each file")
+	unordered = flag.Bool("unordered", false, "Emit NDJSON in worker completion order instead of input order")
+
+	configPath    = flag.String("config", "", "YAML config file (see config.go for fields); explicit flags win over its values")
+	watch         = flag.Bool("watch", false, "Watch the input file(s)/directory and reconvert on change")
+	watchDebounce = flag.Duration("watch-debounce", 500*time.Millisecond, "Debounce window for coalescing rapid file changes in --watch mode")
+)
+
+// sidAllow/sidDeny/severityRemap come from --config; nil means "no filter"
+// / "no remapping" respectively.
+var (
+	sidAllow      map[int]bool
+	sidDeny       map[int]bool
+	severityRemap map[string]string
 )
 
+// workItem pairs an input rules file with the output path it should produce.
+type workItem struct {
+	input  string
+	output string
+}
+
 func main() {
-	flag.Parse()
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "ndjson2rules" {
+		runNdjson2Rules(os.Args[2:])
+		return
+	}
 
+	flag.Parse()
 
-	var filename string
-	if flag.NArg() > 0 {
-		filename = flag.Arg(0)
-	} else {
-		fmt.Println("Error: Please provide a rules file")
-		fmt.Println("Usage: go run main.go <rules_file>")
-		os.Exit(1)
+	if *configPath != "" {
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		applyConfig(cfg)
 	}
 
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatal("Error opening file:", err)
+	if *watch {
+		if err := watchAndConvert(); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
 
-	outputFilename := generateOutputFilename(filename)
-	
-	outputWriter, err := os.Create(outputFilename)
-	if err != nil {
-		log.Fatal("Error creating output file:", err)
+	if err := runOnce(); err != nil {
+		os.Exit(1)
 	}
-	defer outputWriter.Close()
-	
-	log.Printf("Converting %s -> %s", filename, outputFilename)
-
-	ruleCount := 0
-	errorCount := 0
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+}
 
-		rule, err := parseEnhancedRule(line)
+// collectWorkItems resolves --rules-list, --rules-path, or a single
+// positional argument into the list of files to convert.
+func collectWorkItems() ([]workItem, error) {
+	switch {
+	case *rulesList != "":
+		files, err := readRulesList(*rulesList)
 		if err != nil {
-			log.Printf("Error parsing rule %d: %v", ruleCount+1, err)
-			errorCount++
-			continue
+			return nil, err
 		}
-
-		if *filterSID != "" && rule.Metadata != nil {
-			if strconv.Itoa(rule.Metadata.SID) != *filterSID {
-				continue
-			}
+		items := make([]workItem, 0, len(files))
+		for _, f := range files {
+			items = append(items, workItem{input: f, output: outputPathFor(f, "", *outDir)})
 		}
+		return items, nil
 
-		var jsonData []byte
-		var jsonErr error
-
-		if *prettyPrint {
-			jsonData, jsonErr = json.MarshalIndent(rule, "", "  ")
-		} else {
-			jsonData, jsonErr = json.Marshal(rule)
+	case *rulesPath != "":
+		files, err := discoverRuleFiles(*rulesPath)
+		if err != nil {
+			return nil, err
 		}
+		baseDir := *rulesPath
+		if strings.ContainsAny(baseDir, "*?[") {
+			baseDir = globBaseDir(baseDir)
+		}
+		items := make([]workItem, 0, len(files))
+		for _, f := range files {
+			items = append(items, workItem{input: f, output: outputPathFor(f, baseDir, *outDir)})
+		}
+		return items, nil
 
-		if jsonErr != nil {
-			log.Printf("Error marshaling to JSON: %v", jsonErr)
-			errorCount++
-			continue
+	case flag.NArg() > 0:
+		filename := flag.Arg(0)
+		if filename == "-" {
+			return []workItem{{input: "-", output: "-"}}, nil
 		}
+		return []workItem{{input: filename, output: outputPathFor(filename, "", *outDir)}}, nil
+
+	case hasStdinData():
+		return []workItem{{input: "-", output: "-"}}, nil
+
+	default:
+		fmt.Println("Error: Please provide a rules file, --rules-path, --rules-list, or pipe rules in on stdin")
+		fmt.Println("Usage: idsrules2ndjson [flags] <rules_file|->")
+		os.Exit(1)
+		return nil, nil
+	}
+}
 
-		fmt.Fprintln(outputWriter, string(jsonData))
-		ruleCount++
+// processWorkItems converts each item, fanning work out across `jobs`
+// goroutines, and returns the aggregate rule count, parse-error count, and
+// number of files that failed outright (e.g. couldn't be opened).
+func processWorkItems(items []workItem, jobs int) (totalRules, totalErrors, failed int) {
+	if jobs < 1 {
+		jobs = 1
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatal("Error reading input:", err)
+	work := make(chan workItem)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				rules, errs, err := convertFile(item.input, item.output)
+				mu.Lock()
+				totalRules += rules
+				totalErrors += errs
+				if err != nil {
+					log.Printf("Error converting %s: %v", item.input, err)
+					failed++
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	log.Printf("Successfully processed %d rules to %s", ruleCount, outputFilename)
-	if errorCount > 0 {
-		log.Printf("Processed %d rules successfully, %d errors", ruleCount, errorCount)
+	for _, item := range items {
+		work <- item
 	}
-}
+	close(work)
+	wg.Wait()
 
+	return totalRules, totalErrors, failed
+}
 
+// hasStdinData reports whether stdin is piped/redirected data rather than an
+// interactive terminal, so running the tool with no positional argument and
+// no --rules-path/--rules-list (e.g. `curl ... | idsrules2ndjson`) streams
+// stdin instead of printing the usage error.
+func hasStdinData() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
 
 func generateOutputFilename(inputFilename string) string {
 	if lastDot := strings.LastIndex(inputFilename, "."); lastDot != -1 {
@@ -139,27 +218,40 @@ func generateOutputFilename(inputFilename string) string {
 	return inputFilename + ".ndjson"
 }
 
+var validDirections = map[string]bool{"->": true, "<-": true, "<->": true}
+
 func parseEnhancedRule(line string) (*EnhancedRule, error) {
-	ruleRegex := regexp.MustCompile(`^(\w+)\s+(\w+)\s+(\S+)\s+(\S+)\s+(-?>|<->|<-)\s+(\S+)\s+(\S+)\s+\((.+)\)$`)
-	
-	matches := ruleRegex.FindStringSubmatch(line)
-	if len(matches) != 9 {
-		return nil, fmt.Errorf("invalid rule format")
+	fields, optionsBody, err := tokenizeHeader(line)
+	if err != nil {
+		return nil, err
+	}
+
+	direction := fields[4]
+	if !validDirections[direction] {
+		return nil, &parseError{Column: strings.Index(line, direction) + 1, Token: direction, Msg: "invalid direction operator, expected '->', '<-', or '<->'"}
+	}
+
+	decoded, err := decodeOptions(optionsBody)
+	if err != nil {
+		return nil, err
 	}
 
-	options := parseEnhancedOptions(matches[8])
-	
 	rule := &EnhancedRule{
-		Action:     matches[1],
-		Protocol:   matches[2],
-		SourceIP:   matches[3],
-		SourcePort: matches[4],
-		Direction:  matches[5],
-		DestIP:     matches[6],
-		DestPort:   matches[7],
-		Options:    options,
-		ParsedPorts: parsePortInfo(matches[4], matches[7]),
-		Metadata:   extractMetadata(options),
+		Action:      fields[0],
+		Protocol:    fields[1],
+		SourceIP:    fields[2],
+		SourcePort:  fields[3],
+		Direction:   fields[4],
+		DestIP:      fields[5],
+		DestPort:    fields[6],
+		Options:     decoded.Raw,
+		OptionOrder: decoded.Order,
+		Contents:    decoded.Contents,
+		Pcres:       decoded.Pcres,
+		Flow:        decoded.Flow,
+		Threshold:   decoded.Threshold,
+		ParsedPorts: parsePortInfo(fields[3], fields[6]),
+		Metadata:    extractMetadata(decoded),
 	}
 
 	if *includeRaw {
@@ -169,77 +261,45 @@ func parseEnhancedRule(line string) (*EnhancedRule, error) {
 	return rule, nil
 }
 
-func parseEnhancedOptions(optionsStr string) map[string]interface{} {
-	options := make(map[string]interface{})
-	
-	parts := splitOptions(optionsStr)
-	
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-
-		colonIndex := strings.Index(part, ":")
-		if colonIndex == -1 {
-			options[part] = true
-			continue
-		}
-
-		key := strings.TrimSpace(part[:colonIndex])
-		value := strings.TrimSpace(part[colonIndex+1:])
-		
-		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
-			value = value[1 : len(value)-1]
-		}
-
-		if num, err := strconv.Atoi(value); err == nil {
-			options[key] = num
-		} else if num, err := strconv.ParseFloat(value, 64); err == nil {
-			options[key] = num
-		} else {
-			options[key] = value
-		}
-	}
-
-	return options
-}
-
 func parsePortInfo(sourcePort, destPort string) *PortInfo {
 	info := &PortInfo{}
-	
+
 	if sourcePort != "any" {
 		info.SourcePorts = parsePortString(sourcePort)
 	}
-	
+
 	if destPort != "any" {
 		info.DestinationPorts = parsePortString(destPort)
 	}
-	
+
 	if len(info.SourcePorts) == 0 && len(info.DestinationPorts) == 0 {
 		return nil
 	}
-	
+
 	return info
 }
 
 func parsePortString(portStr string) []string {
 	portStr = strings.Trim(portStr, "[]")
-	
+
 	ports := strings.Split(portStr, ",")
 	var result []string
-	
+
 	for _, port := range ports {
 		result = append(result, strings.TrimSpace(port))
 	}
-	
+
 	return result
 }
 
-func extractMetadata(options map[string]interface{}) *RuleMetadata {
-	metadata := &RuleMetadata{}
-	
-	if sid, exists := options["sid"]; exists {
+func extractMetadata(decoded *decodedOptions) *RuleMetadata {
+	metadata := &RuleMetadata{
+		CVEs:       decoded.CVEs,
+		References: decoded.References,
+		Tags:       decoded.Metadata,
+	}
+
+	if sid, exists := decoded.Raw["sid"]; exists {
 		if sidInt, ok := sid.(int); ok {
 			metadata.SID = sidInt
 		} else if sidStr, ok := sid.(string); ok {
@@ -248,8 +308,8 @@ func extractMetadata(options map[string]interface{}) *RuleMetadata {
 			}
 		}
 	}
-	
-	if rev, exists := options["rev"]; exists {
+
+	if rev, exists := decoded.Raw["rev"]; exists {
 		if revInt, ok := rev.(int); ok {
 			metadata.Revision = revInt
 		} else if revStr, ok := rev.(string); ok {
@@ -258,56 +318,20 @@ func extractMetadata(options map[string]interface{}) *RuleMetadata {
 			}
 		}
 	}
-	
-	if ref, exists := options["reference"]; exists {
-		if refStr, ok := ref.(string); ok {
-			if strings.HasPrefix(refStr, "cve,") {
-				cve := strings.TrimPrefix(refStr, "cve,")
-				metadata.CVEs = append(metadata.CVEs, cve)
-			}
-			metadata.References = append(metadata.References, refStr)
-		}
-	}
-	
-	if classtype, exists := options["classtype"]; exists {
+
+	if classtype, exists := decoded.Raw["classtype"]; exists {
 		if classtypeStr, ok := classtype.(string); ok {
 			metadata.Severity = classtypeStr
+			if remapped, ok := severityRemap[classtypeStr]; ok {
+				metadata.Severity = remapped
+			}
 		}
 	}
-	
-	if metadata.SID == 0 && metadata.Revision == 0 && len(metadata.CVEs) == 0 && 
-	   len(metadata.References) == 0 && metadata.Severity == "" {
+
+	if metadata.SID == 0 && metadata.Revision == 0 && len(metadata.CVEs) == 0 &&
+		len(metadata.References) == 0 && metadata.Severity == "" && len(metadata.Tags) == 0 {
 		return nil
 	}
-	
-	return metadata
-}
 
-func splitOptions(optionsStr string) []string {
-	var parts []string
-	var current strings.Builder
-	inQuotes := false
-	
-	for _, char := range optionsStr {
-		switch char {
-		case '"':
-			inQuotes = !inQuotes
-			current.WriteRune(char)
-		case ';':
-			if !inQuotes {
-				parts = append(parts, current.String())
-				current.Reset()
-			} else {
-				current.WriteRune(char)
-			}
-		default:
-			current.WriteRune(char)
-		}
-	}
-	
-	if current.Len() > 0 {
-		parts = append(parts, current.String())
-	}
-	
-	return parts
+	return metadata
 }
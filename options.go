@@ -0,0 +1,319 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ContentMatch is a single content: match, with the sticky-buffer and
+// distance/offset modifiers that follow it in the option list folded in.
+type ContentMatch struct {
+	Pattern  string `json:"pattern"`
+	Negated  bool   `json:"negated,omitempty"`
+	Nocase   bool   `json:"nocase,omitempty"`
+	Depth    int    `json:"depth,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Within   int    `json:"within,omitempty"`
+	Distance int    `json:"distance,omitempty"`
+	Buffer   string `json:"buffer,omitempty"`
+}
+
+// PcreMatch is a single pcre: match split into its regex and trailing flags
+// (e.g. "i", "R", "U" for the sticky-buffer-less legacy modifiers).
+type PcreMatch struct {
+	Pattern string `json:"pattern"`
+	Flags   string `json:"flags,omitempty"`
+	Buffer  string `json:"buffer,omitempty"`
+}
+
+// FlowInfo is the decoded flow: keyword.
+type FlowInfo struct {
+	Established bool `json:"established,omitempty"`
+	Stateless   bool `json:"stateless,omitempty"`
+	ToServer    bool `json:"to_server,omitempty"`
+	ToClient    bool `json:"to_client,omitempty"`
+}
+
+// ThresholdInfo is the decoded threshold: or detection_filter: keyword.
+type ThresholdInfo struct {
+	Type    string `json:"type,omitempty"`
+	Track   string `json:"track,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Seconds int    `json:"seconds,omitempty"`
+}
+
+// Reference is one reference: entry, e.g. "cve,2021-1234" -> {cve, 2021-1234}.
+type Reference struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// decodedOptions holds the typed views extracted from a rule's options
+// block alongside Raw, the original lossy map[string]interface{} kept for
+// option keys that don't yet have a typed representation.
+type decodedOptions struct {
+	Raw        map[string]interface{}
+	Order      []string
+	Contents   []ContentMatch
+	Pcres      []PcreMatch
+	Flow       *FlowInfo
+	Threshold  *ThresholdInfo
+	References []Reference
+	CVEs       []string
+	Metadata   map[string]string
+}
+
+// stickyBuffers are the Suricata "sticky buffer" keywords that redirect
+// subsequent content/pcre matches at a specific HTTP field instead of the
+// raw packet payload. Suricata resets the buffer back to pkt_data after
+// each content/pcre in the legacy modifier style, but in practice rule
+// authors use the modern sticky-buffer style where it stays active until
+// another sticky buffer keyword appears; we follow that convention here.
+var stickyBuffers = map[string]bool{
+	"http.uri": true, "http.header": true, "http.method": true,
+	"http.user_agent": true, "http.host": true, "http.cookie": true,
+	"http.request_body": true, "http.response_body": true,
+}
+
+// decodeOptions parses a rule's options body into both the legacy lossy map
+// and the typed fields SIEM ingestion pipelines actually want to query on.
+func decodeOptions(optionsStr string) (*decodedOptions, error) {
+	parts, err := tokenizeOptions(optionsStr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decodedOptions{Raw: make(map[string]interface{})}
+	buffer := ""
+	seenCVE := make(map[string]bool)
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d.Order = append(d.Order, part)
+
+		colonIndex := strings.Index(part, ":")
+		if colonIndex == -1 {
+			if stickyBuffers[part] {
+				buffer = part
+			}
+			d.Raw[part] = true
+			continue
+		}
+
+		key := strings.TrimSpace(part[:colonIndex])
+		value := unquote(strings.TrimSpace(part[colonIndex+1:]))
+
+		switch key {
+		case "content":
+			negated := false
+			if strings.HasPrefix(value, "!") {
+				negated = true
+				value = strings.TrimPrefix(value, "!")
+			}
+			d.Contents = append(d.Contents, ContentMatch{Pattern: value, Negated: negated, Buffer: buffer})
+			d.Raw[key] = setOrAppendString(d.Raw[key], value)
+
+		case "nocase":
+			if n := len(d.Contents); n > 0 {
+				d.Contents[n-1].Nocase = true
+			}
+			d.Raw[key] = true
+
+		case "depth", "offset", "within", "distance":
+			n, _ := strconv.Atoi(value)
+			if len(d.Contents) > 0 {
+				applyContentModifier(&d.Contents[len(d.Contents)-1], key, n)
+			}
+			d.Raw[key] = n
+
+		case "pcre":
+			pattern, flags := splitPcre(value)
+			d.Pcres = append(d.Pcres, PcreMatch{Pattern: pattern, Flags: flags, Buffer: buffer})
+			d.Raw[key] = value
+
+		case "flow":
+			d.Flow = parseFlow(value)
+			d.Raw[key] = value
+
+		case "threshold", "detection_filter":
+			d.Threshold = parseThreshold(value)
+			d.Raw[key] = value
+
+		case "metadata":
+			d.Metadata = parseMetadataKV(value)
+			d.Raw[key] = value
+
+		case "reference":
+			ref := parseReference(value)
+			d.References = append(d.References, ref)
+			if ref.Type == "cve" && !seenCVE[ref.Value] {
+				seenCVE[ref.Value] = true
+				d.CVEs = append(d.CVEs, ref.Value)
+			}
+			d.Raw[key] = setOrAppendString(d.Raw[key], value)
+
+		default:
+			if stickyBuffers[key] {
+				buffer = key
+			}
+			d.Raw[key] = typedScalar(value)
+		}
+	}
+
+	return d, nil
+}
+
+func applyContentModifier(c *ContentMatch, key string, n int) {
+	switch key {
+	case "depth":
+		c.Depth = n
+	case "offset":
+		c.Offset = n
+	case "within":
+		c.Within = n
+	case "distance":
+		c.Distance = n
+	}
+}
+
+// unquote strips a single pair of surrounding double quotes, same as the
+// original parser did for every option value, then resolves the '\"'/'\;'
+// escapes tokenizeOptions deliberately leaves in place so it can return each
+// option's unmodified source text for OptionOrder.
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	return unescapeOptionValue(value)
+}
+
+// unescapeOptionValue resolves the backslash escapes Suricata allows inside
+// quoted option values ('\"' and '\;') into their literal characters.
+func unescapeOptionValue(value string) string {
+	if !strings.ContainsRune(value, '\\') {
+		return value
+	}
+
+	runes := []rune(value)
+	var out []rune
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == ';') {
+			out = append(out, runes[i+1])
+			i++
+			continue
+		}
+		out = append(out, runes[i])
+	}
+	return string(out)
+}
+
+// typedScalar mirrors the original parseEnhancedOptions behavior for option
+// keys with no dedicated typed field: numbers and floats get their Go type,
+// everything else stays a string.
+func typedScalar(value string) interface{} {
+	if num, err := strconv.Atoi(value); err == nil {
+		return num
+	}
+	if num, err := strconv.ParseFloat(value, 64); err == nil {
+		return num
+	}
+	return value
+}
+
+// setOrAppendString keeps the legacy Raw map's single-value-per-key
+// convenience for the first occurrence of a repeatable option, then
+// collapses to a slice once there's more than one (content and reference
+// can both repeat many times in a single rule).
+func setOrAppendString(existing interface{}, value string) interface{} {
+	switch v := existing.(type) {
+	case nil:
+		return value
+	case string:
+		return []string{v, value}
+	case []string:
+		return append(v, value)
+	default:
+		return value
+	}
+}
+
+// splitPcre separates a pcre: value ("/pattern/flags") into its regex and
+// trailing flag letters.
+func splitPcre(value string) (pattern, flags string) {
+	if len(value) < 2 || value[0] != '/' {
+		return value, ""
+	}
+	if end := strings.LastIndex(value, "/"); end > 0 {
+		return value[1:end], value[end+1:]
+	}
+	return value[1:], ""
+}
+
+// parseFlow decodes flow:established,to_server,no_stream style values.
+func parseFlow(value string) *FlowInfo {
+	info := &FlowInfo{}
+	for _, tok := range strings.Split(value, ",") {
+		switch strings.TrimSpace(tok) {
+		case "established":
+			info.Established = true
+		case "stateless":
+			info.Stateless = true
+		case "to_server", "from_client":
+			info.ToServer = true
+		case "to_client", "from_server":
+			info.ToClient = true
+		}
+	}
+	return info
+}
+
+// parseThreshold decodes threshold:type limit,track by_src,count 5,seconds 60.
+func parseThreshold(value string) *ThresholdInfo {
+	info := &ThresholdInfo{}
+	for _, tok := range strings.Split(value, ",") {
+		fields := strings.Fields(strings.TrimSpace(tok))
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "type":
+			info.Type = fields[1]
+		case "track":
+			info.Track = fields[1]
+		case "count":
+			info.Count, _ = strconv.Atoi(fields[1])
+		case "seconds":
+			info.Seconds, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return info
+}
+
+// parseMetadataKV decodes metadata:former_category ET, created_at 2019_01_01
+// into a flat key/value map, matching Suricata's "keyword value" convention.
+func parseMetadataKV(value string) map[string]string {
+	kv := make(map[string]string)
+	for _, tok := range strings.Split(value, ",") {
+		fields := strings.Fields(strings.TrimSpace(tok))
+		if len(fields) < 2 {
+			continue
+		}
+		kv[fields[0]] = strings.Join(fields[1:], " ")
+	}
+	if len(kv) == 0 {
+		return nil
+	}
+	return kv
+}
+
+// parseReference decodes a reference:type,value entry, e.g.
+// "cve,2021-1234" or "url,example.com/advisory".
+func parseReference(value string) Reference {
+	if idx := strings.Index(value, ","); idx != -1 {
+		return Reference{Type: strings.TrimSpace(value[:idx]), Value: strings.TrimSpace(value[idx+1:])}
+	}
+	return Reference{Value: value}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeOptionsStickyBuffers(t *testing.T) {
+	d, err := decodeOptions(`http.uri; content:"foo"; content:"bar"; http.header; content:"baz"; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	if len(d.Contents) != 3 {
+		t.Fatalf("got %d contents, want 3: %+v", len(d.Contents), d.Contents)
+	}
+	if d.Contents[0].Buffer != "http.uri" {
+		t.Errorf("contents[0].Buffer = %q, want http.uri", d.Contents[0].Buffer)
+	}
+	if d.Contents[1].Buffer != "http.uri" {
+		t.Errorf("contents[1].Buffer = %q, want http.uri (sticky until next buffer keyword)", d.Contents[1].Buffer)
+	}
+	if d.Contents[2].Buffer != "http.header" {
+		t.Errorf("contents[2].Buffer = %q, want http.header", d.Contents[2].Buffer)
+	}
+}
+
+func TestDecodeOptionsThreshold(t *testing.T) {
+	d, err := decodeOptions(`threshold:type limit, track by_src, count 5, seconds 60; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	want := &ThresholdInfo{Type: "limit", Track: "by_src", Count: 5, Seconds: 60}
+	if !reflect.DeepEqual(d.Threshold, want) {
+		t.Errorf("Threshold = %+v, want %+v", d.Threshold, want)
+	}
+}
+
+func TestDecodeOptionsDetectionFilter(t *testing.T) {
+	d, err := decodeOptions(`detection_filter:track by_dst, count 10, seconds 30; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	want := &ThresholdInfo{Track: "by_dst", Count: 10, Seconds: 30}
+	if !reflect.DeepEqual(d.Threshold, want) {
+		t.Errorf("Threshold = %+v, want %+v", d.Threshold, want)
+	}
+}
+
+func TestDecodeOptionsMetadata(t *testing.T) {
+	d, err := decodeOptions(`metadata:former_category ET, created_at 2019_01_01; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	want := map[string]string{"former_category": "ET", "created_at": "2019_01_01"}
+	if !reflect.DeepEqual(d.Metadata, want) {
+		t.Errorf("Metadata = %+v, want %+v", d.Metadata, want)
+	}
+}
+
+func TestDecodeOptionsReferenceAndCVEDedup(t *testing.T) {
+	d, err := decodeOptions(`reference:cve,2021-1234; reference:url,example.com/a; reference:cve,2021-1234; reference:cve,2022-5678; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	wantRefs := []Reference{
+		{Type: "cve", Value: "2021-1234"},
+		{Type: "url", Value: "example.com/a"},
+		{Type: "cve", Value: "2021-1234"},
+		{Type: "cve", Value: "2022-5678"},
+	}
+	if !reflect.DeepEqual(d.References, wantRefs) {
+		t.Errorf("References = %+v, want %+v", d.References, wantRefs)
+	}
+
+	wantCVEs := []string{"2021-1234", "2022-5678"}
+	if !reflect.DeepEqual(d.CVEs, wantCVEs) {
+		t.Errorf("CVEs = %+v, want %+v (duplicate CVE reference should not repeat)", d.CVEs, wantCVEs)
+	}
+}
+
+func TestDecodeOptionsByteTestFallsThroughToRaw(t *testing.T) {
+	d, err := decodeOptions(`byte_test:4,=,1234,0; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	got, ok := d.Raw["byte_test"]
+	if !ok {
+		t.Fatalf("Raw[byte_test] missing, want it present via the default-case fallback")
+	}
+	if got != "4,=,1234,0" {
+		t.Errorf("Raw[byte_test] = %v, want %q", got, "4,=,1234,0")
+	}
+}
+
+func TestDecodeOptionsPcreWithEmbeddedSemicolon(t *testing.T) {
+	d, err := decodeOptions(`pcre:"/a;b/i"; sid:1;`)
+	if err != nil {
+		t.Fatalf("decodeOptions: %v", err)
+	}
+	if len(d.Pcres) != 1 {
+		t.Fatalf("got %d pcres, want 1: %+v", len(d.Pcres), d.Pcres)
+	}
+	if d.Pcres[0].Pattern != "a;b" || d.Pcres[0].Flags != "i" {
+		t.Errorf("Pcres[0] = %+v, want Pattern=%q Flags=%q", d.Pcres[0], "a;b", "i")
+	}
+}
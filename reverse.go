@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// runNdjson2Rules implements the `ndjson2rules` subcommand: the inverse of
+// the default conversion, turning NDJSON produced by this tool back into
+// Suricata/Snort rule text. It reads EnhancedRule JSON objects one per line
+// and writes one reconstructed rule line per input record, using
+// OptionOrder to restore the original option ordering rather than
+// re-deriving it from the typed fields.
+func runNdjson2Rules(args []string) {
+	fs := flag.NewFlagSet("ndjson2rules", flag.ExitOnError)
+	fs.Parse(args)
+
+	input := os.Stdin
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("opening %s: %v", fs.Arg(0), err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rule EnhancedRule
+		if err := json.Unmarshal([]byte(line), &rule); err != nil {
+			log.Fatalf("line %d: decoding NDJSON record: %v", lineNo, err)
+		}
+
+		fmt.Fprintln(out, renderRule(&rule))
+	}
+	if err := scanner.Err(); err != nil {
+		if err == io.EOF {
+			return
+		}
+		log.Fatalf("reading NDJSON: %v", err)
+	}
+}
+
+// renderRule reconstructs rule text from an EnhancedRule. The options block
+// is rebuilt from OptionOrder when present, which preserves the exact
+// ordering (and formatting quirks) of the original rule; it falls back to
+// the typed/Raw fields only for records that predate OptionOrder.
+func renderRule(rule *EnhancedRule) string {
+	header := fmt.Sprintf("%s %s %s %s %s %s %s",
+		rule.Action, rule.Protocol, rule.SourceIP, rule.SourcePort,
+		rule.Direction, rule.DestIP, rule.DestPort)
+
+	options := rule.OptionOrder
+	if len(options) == 0 {
+		options = optionsFromRaw(rule.Options)
+	}
+
+	var body strings.Builder
+	for _, opt := range options {
+		body.WriteString(opt)
+		body.WriteString("; ")
+	}
+
+	return fmt.Sprintf("%s (%s)", header, body.String())
+}
+
+// optionsFromRaw rebuilds a "key:value" option list from the lossy Raw map
+// for EnhancedRule records that have no OptionOrder (e.g. ones produced by
+// an older version of this tool). Map iteration order isn't stable, so this
+// is best-effort and only used as a fallback.
+func optionsFromRaw(raw map[string]interface{}) []string {
+	options := make([]string, 0, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case bool:
+			options = append(options, key)
+		case []string:
+			for _, s := range v {
+				options = append(options, fmt.Sprintf("%s:%s", key, s))
+			}
+		default:
+			options = append(options, fmt.Sprintf("%s:%v", key, v))
+		}
+	}
+	return options
+}
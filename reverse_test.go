@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRoundTrip checks that parsing a rule, rendering it back to rule text
+// with renderRule, and parsing that text again yields an EnhancedRule
+// equivalent to the first parse (RawRule legitimately differs, since
+// renderRule doesn't reproduce the original's exact whitespace).
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{
+			name: "simple content match",
+			rule: `alert tcp any any -> any 80 (msg:"test"; content:"GET"; sid:1; rev:1;)`,
+		},
+		{
+			name: "sticky buffer and pcre",
+			rule: `alert http $HOME_NET any -> $EXTERNAL_NET any (msg:"http"; http.uri; content:"/admin"; pcre:"/login$/i"; sid:2; rev:3;)`,
+		},
+		{
+			name: "flow, threshold, and references",
+			rule: `alert tcp any any -> any any (msg:"scan"; flow:established,to_server; threshold:type limit, track by_src, count 5, seconds 60; reference:cve,2021-1234; reference:url,example.com/advisory; classtype:attempted-recon; sid:3; rev:1;)`,
+		},
+		{
+			name: "negated content with modifiers",
+			rule: `alert tcp any any -> any any (msg:"neg"; content:!"bad"; depth:10; offset:2; sid:4; rev:2;)`,
+		},
+		{
+			name: "metadata keyword",
+			rule: `alert tcp any any -> any any (msg:"meta"; metadata:former_category ET, created_at 2019_01_01; sid:5; rev:1;)`,
+		},
+		{
+			name: "escaped quote and semicolon in content",
+			rule: `alert tcp any any -> any any (msg:"esc"; content:"a\"b\;c"; sid:103; rev:1;)`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			first, err := parseEnhancedRule(tc.rule)
+			if err != nil {
+				t.Fatalf("parsing original rule: %v", err)
+			}
+
+			rendered := renderRule(first)
+
+			second, err := parseEnhancedRule(rendered)
+			if err != nil {
+				t.Fatalf("parsing rendered rule %q: %v", rendered, err)
+			}
+
+			first.RawRule = ""
+			second.RawRule = ""
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("round trip mismatch\noriginal: %+v\nrendered: %q\nreparsed: %+v", first, rendered, second)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// parseRequest is the JSON body /v1/parse accepts as an alternative to
+// posting the rule text directly.
+type parseRequest struct {
+	Text string `json:"text"`
+}
+
+// parseResponse mirrors the CLI's NDJSON record on success; ParseError is
+// set instead of Rule when the posted text didn't parse.
+type parseResponse struct {
+	Rule       *EnhancedRule `json:"rule,omitempty"`
+	ParseError string        `json:"parse_error,omitempty"`
+}
+
+// runServe starts an HTTP/JSON service exposing rule parsing over the
+// network, so callers that can't shell out to the CLI can normalize rules
+// inline: POST a rule line to /v1/parse and get back the same EnhancedRule
+// JSON the CLI writes to NDJSON (or a parse_error on failure).
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "HTTP listen address")
+	fs.Parse(args)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/parse", handleParse)
+
+	log.Printf("Rule parser HTTP service listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		log.Fatal(fmt.Errorf("HTTP server: %w", err))
+	}
+}
+
+// handleParse parses a single rule line posted as a JSON {"text": "..."}
+// body, or as raw text/plain, and responds with the parsed EnhancedRule.
+func handleParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := parseResponse{}
+	rule, err := parseEnhancedRule(extractRuleText(body, r.Header.Get("Content-Type")))
+	if err != nil {
+		resp.ParseError = err.Error()
+	} else {
+		resp.Rule = rule
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("encoding /v1/parse response: %v", err)
+	}
+}
+
+// extractRuleText pulls the rule text to parse out of a request body: a
+// JSON {"text": "..."} object if Content-Type says JSON, otherwise the raw
+// body text.
+func extractRuleText(body []byte, contentType string) string {
+	if strings.HasPrefix(contentType, "application/json") {
+		var req parseRequest
+		if err := json.Unmarshal(body, &req); err == nil {
+			return req.Text
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// parseError is a structured parse failure: where in the line it happened
+// (byte column, 1-indexed) and what token was being read when it did, so
+// users working through a noisy ruleset get something more actionable than
+// "invalid rule format".
+type parseError struct {
+	Line   int
+	Column int
+	Token  string
+	Msg    string
+}
+
+func (e *parseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s (near %q)", e.Line, e.Column, e.Msg, e.Token)
+}
+
+// tokenizeHeader walks line rune-by-rune tracking bracket depth (for
+// variable groups like [$HOME_NET,!$EXTERNAL_NET] and port lists like
+// [80,443]), quote state, and backslash escapes, and splits it into the
+// seven whitespace-delimited header fields (action, protocol, src, sport,
+// dir, dst, dport) plus the raw options body between the outermost
+// parentheses. Unlike a plain strings.Fields/regex split, it never breaks a
+// bracketed group apart on internal whitespace.
+func tokenizeHeader(line string) (fields []string, optionsBody string, err error) {
+	runes := []rune(line)
+	n := len(runes)
+
+	i := 0
+	skipSpace := func() {
+		for i < n && unicode.IsSpace(runes[i]) {
+			i++
+		}
+	}
+
+	for len(fields) < 7 {
+		skipSpace()
+		if i >= n {
+			return nil, "", &parseError{Column: i + 1, Msg: fmt.Sprintf("expected %d header fields, found %d", 7, len(fields))}
+		}
+
+		start := i
+		depth := 0
+		for i < n {
+			c := runes[i]
+			switch {
+			case c == '[':
+				depth++
+			case c == ']':
+				if depth > 0 {
+					depth--
+				}
+			}
+			if unicode.IsSpace(c) && depth == 0 {
+				break
+			}
+			i++
+		}
+		if depth > 0 {
+			return nil, "", &parseError{Column: start + 1, Token: string(runes[start:i]), Msg: "unterminated '[' group in rule header"}
+		}
+
+		fields = append(fields, string(runes[start:i]))
+	}
+
+	skipSpace()
+	if i >= n || runes[i] != '(' {
+		got := "end of line"
+		if i < n {
+			got = string(runes[i])
+		}
+		return nil, "", &parseError{Column: i + 1, Token: got, Msg: "expected '(' to start rule options"}
+	}
+
+	openAt := i
+	i++ // consume '('
+	bodyStart := i
+	depth := 1
+	inQuotes := false
+	for i < n && depth > 0 {
+		c := runes[i]
+		switch {
+		case c == '\\' && inQuotes:
+			i++ // skip the escaped rune too
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == '(' && !inQuotes:
+			depth++
+		case c == ')' && !inQuotes:
+			depth--
+		}
+		i++
+	}
+	if depth != 0 {
+		return nil, "", &parseError{Column: openAt + 1, Msg: "unterminated '(' options block"}
+	}
+
+	optionsBody = string(runes[bodyStart : i-1])
+
+	skipSpace()
+	if i != n {
+		return nil, "", &parseError{Column: i + 1, Token: string(runes[i:]), Msg: "unexpected trailing data after options block"}
+	}
+
+	return fields, optionsBody, nil
+}
+
+// tokenizeOptions splits a rule's options body into individual "key:value"
+// (or bare keyword) segments on unescaped, unquoted ';'. It honors '\;'
+// and '\"' escapes inside quoted values (e.g. content:"a\;b"; pcre:"/a;b/i";)
+// so neither breaks a single option apart. The escape/quote tracking is only
+// used to find segment boundaries: each returned segment is the untouched
+// source slice, backslashes and all, so callers that need the literal
+// original text (OptionOrder) get it verbatim. Resolving the escapes into a
+// decoded value is options.go's job (see unquote).
+func tokenizeOptions(optionsBody string) ([]string, error) {
+	var parts []string
+	inQuotes := false
+
+	runes := []rune(optionsBody)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(runes):
+			i++ // skip the escaped rune without touching the raw text
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == ';' && !inQuotes:
+			parts = append(parts, string(runes[start:i]))
+			start = i + 1
+		}
+	}
+
+	if inQuotes {
+		return nil, &parseError{Column: len(runes) + 1, Msg: "unterminated quoted value in options"}
+	}
+
+	if start < len(runes) {
+		parts = append(parts, string(runes[start:]))
+	}
+
+	return parts, nil
+}
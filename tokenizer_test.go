@@ -0,0 +1,120 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		wantFields  []string
+		wantOptions string
+		wantErr     bool
+	}{
+		{
+			name:        "plain header",
+			line:        `alert tcp any any -> any 80 (sid:1;)`,
+			wantFields:  []string{"alert", "tcp", "any", "any", "->", "any", "80"},
+			wantOptions: "sid:1;",
+		},
+		{
+			name:        "bracketed variable group with no internal spaces",
+			line:        `alert tcp $HOME_NET any -> [$EXTERNAL_NET,!$TRUSTED_NET] any (sid:2;)`,
+			wantFields:  []string{"alert", "tcp", "$HOME_NET", "any", "->", "[$EXTERNAL_NET,!$TRUSTED_NET]", "any"},
+			wantOptions: "sid:2;",
+		},
+		{
+			name:        "bracketed IP/port list with embedded spaces isn't split on them",
+			line:        `alert tcp any any -> [10.0.0.1, 10.0.0.2] [80, 443] (sid:3;)`,
+			wantFields:  []string{"alert", "tcp", "any", "any", "->", "[10.0.0.1, 10.0.0.2]", "[80, 443]"},
+			wantOptions: "sid:3;",
+		},
+		{
+			name:        "tab-separated header fields",
+			line:        "alert\ttcp\tany\tany\t->\tany\tany\t(sid:4;)",
+			wantFields:  []string{"alert", "tcp", "any", "any", "->", "any", "any"},
+			wantOptions: "sid:4;",
+		},
+		{
+			name:    "unterminated bracket group is an error",
+			line:    `alert tcp any any -> [10.0.0.1 any (sid:5;)`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated options block is an error",
+			line:    `alert tcp any any -> any any (sid:6;`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, options, err := tokenizeHeader(tc.line)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got fields=%v options=%q", fields, options)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tokenizeHeader: %v", err)
+			}
+			if !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tc.wantFields)
+			}
+			if options != tc.wantOptions {
+				t.Errorf("options = %q, want %q", options, tc.wantOptions)
+			}
+		})
+	}
+}
+
+func TestTokenizeOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "simple options",
+			body: `msg:"hi"; sid:1; rev:1;`,
+			want: []string{`msg:"hi"`, " sid:1", " rev:1"},
+		},
+		{
+			name: "pcre with an embedded semicolon inside slashes",
+			body: `pcre:"/a;b/i"; sid:2;`,
+			want: []string{`pcre:"/a;b/i"`, " sid:2"},
+		},
+		{
+			name: "escaped semicolon and quote stay in the raw segment",
+			body: `content:"a\;b\"c"; sid:3;`,
+			want: []string{`content:"a\;b\"c"`, " sid:3"},
+		},
+		{
+			name:    "unterminated quote is an error",
+			body:    `content:"unterminated; sid:4;`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tokenizeOptions(tc.body)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tokenizeOptions: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
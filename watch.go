@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchAndConvert runs one conversion pass immediately, then watches the
+// same input (a single file, --rules-path directory, or --rules-list
+// entries) and reconverts whenever a .rules file changes, coalescing
+// bursts of writes (e.g. an editor save) within --watch-debounce.
+func watchAndConvert() error {
+	if err := runOnce(); err != nil {
+		log.Printf("initial conversion: %v", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range watchDirs() {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+	}
+	log.Printf("watch: monitoring %d director(y/ies) for .rules changes", len(watcher.WatchList()))
+
+	var debounce *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !strings.HasSuffix(event.Name, ".rules") {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			if debounce == nil {
+				debounce = time.AfterFunc(*watchDebounce, func() {
+					if err := runOnce(); err != nil {
+						log.Printf("reconversion: %v", err)
+					}
+				})
+			} else {
+				debounce.Reset(*watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("watch error: %v", err)
+		}
+	}
+}
+
+// watchDirs returns the directories fsnotify should watch. For a single
+// file or --rules-list it's the directory containing each file,
+// deduplicated; for --rules-path it's that directory plus every
+// subdirectory beneath it, since fsnotify doesn't watch recursively and
+// conversion (collectWorkItems/discoverRuleFiles) does recurse.
+func watchDirs() []string {
+	var files []string
+	switch {
+	case *rulesList != "":
+		if list, err := readRulesList(*rulesList); err == nil {
+			files = list
+		}
+	case *rulesPath != "":
+		dirs, err := discoverRuleDirs(*rulesPath)
+		if err != nil {
+			log.Printf("watch: listing subdirectories of %s: %v", *rulesPath, err)
+			return []string{*rulesPath}
+		}
+		return dirs
+	case flag.NArg() > 0:
+		files = []string{flag.Arg(0)}
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// runOnce performs a single collect-and-convert pass, reusing the same
+// flags the non-watch code path does.
+func runOnce() error {
+	items, err := collectWorkItems()
+	if err != nil {
+		return err
+	}
+
+	totalRules, totalErrors, failed := processWorkItems(items, *jobs)
+	log.Printf("Processed %d file(s): %d rules, %d parse errors", len(items), totalRules, totalErrors)
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) failed to convert", failed)
+	}
+	return nil
+}